@@ -0,0 +1,320 @@
+package timemachine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Clock is the behaviour this package swaps in for the time package: a
+// source of Now/Since/Until/Sleep plus the timer/ticker family from
+// timer.go. Library code that wants to be testable without depending on
+// this package's global state should accept a Clock (or pull one from a
+// context via FromContext) instead of calling time.Now() directly.
+//
+// RealClock and *FakeClock both implement Clock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) *Timer
+	NewTicker(d time.Duration) *Ticker
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) *Timer
+}
+
+// RealClock implements Clock using the real wall clock, with no
+// freeze/travel/speed capability. It is the Clock production code gets by
+// default from FromContext, so tests can substitute a FakeClock without
+// production code paths changing.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// Since returns time.Since(t).
+func (RealClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// Until returns time.Until(t).
+func (RealClock) Until(t time.Time) time.Duration { return time.Until(t) }
+
+// Sleep calls time.Sleep(d).
+func (RealClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// NewTimer wraps time.NewTimer(d).
+func (RealClock) NewTimer(d time.Duration) *Timer {
+	rt := time.NewTimer(d)
+	return &Timer{C: rt.C, real: rt}
+}
+
+// NewTicker wraps time.NewTicker(d).
+func (RealClock) NewTicker(d time.Duration) *Ticker {
+	rt := time.NewTicker(d)
+	return &Ticker{C: rt.C, real: rt}
+}
+
+// After wraps time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// AfterFunc wraps time.AfterFunc(d, f).
+func (RealClock) AfterFunc(d time.Duration, f func()) *Timer {
+	rt := time.AfterFunc(d, f)
+	return &Timer{real: rt}
+}
+
+// FakeClock is an instance-scoped equivalent of this package's global
+// frozen/scaled state and timer scheduler: Freeze/Unfreeze/Travel/SetSpeed
+// are all scoped to the FakeClock they are called on, instead of to a single
+// package-level singleton. This lets parallel tests (t.Parallel) each hold
+// their own clock without trampling one another, and lets library code
+// thread a Clock explicitly instead of reaching for the package globals.
+//
+// The zero value is a usable, unfrozen, unscaled FakeClock; most callers
+// should use NewFake(t) instead to get one wired up for a test.
+type FakeClock struct {
+	mu         sync.Mutex
+	frozen     bool
+	frozenTime time.Time
+
+	// scaled, anchor, base and factor back SetSpeed. When scaled is true and
+	// frozen is false, virtual time advances at factor times the real wall
+	// clock, starting from base at real time anchor.
+	scaled bool
+	anchor time.Time
+	base   time.Time
+	factor float64
+
+	schedOnce sync.Once
+	schedMu   sync.Mutex
+	cond      *sync.Cond
+	pending   waiterHeap
+
+	monoOnce  sync.Once
+	monoEpoch time.Time
+
+	recMu     sync.Mutex
+	recording bool
+	events    []Event
+}
+
+// NewFake returns a frozen *FakeClock scoped to a single test, and registers
+// its Unfreeze via t.Cleanup. Use it instead of the package-level
+// FreezeNow()/Unfreeze() globals whenever a test runs t.Parallel(), so it
+// gets its own isolated clock rather than sharing (and fighting over) the
+// package's default one.
+func NewFake(t testing.TB) *FakeClock {
+	t.Helper()
+	c := &FakeClock{}
+	c.Freeze()
+	t.Cleanup(c.Unfreeze)
+	return c
+}
+
+func (c *FakeClock) initSched() {
+	c.schedOnce.Do(func() { c.cond = sync.NewCond(&c.schedMu) })
+}
+
+// Now behaves like the package-level Now(), scoped to this clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	now := c.virtualNowLocked()
+	c.mu.Unlock()
+	c.record(Event{Kind: "Now", At: now})
+	return now
+}
+
+// virtualNowLocked computes Now() assuming c.mu is already held.
+func (c *FakeClock) virtualNowLocked() time.Time {
+	switch {
+	case c.frozen:
+		return c.frozenTime
+	case c.scaled:
+		return c.base.Add(time.Duration(float64(time.Since(c.anchor)) * c.factor))
+	default:
+		return time.Now()
+	}
+}
+
+// Monotonic returns a nanosecond counter that honors this clock's
+// frozen/scaled state the same way Now() does: it jumps forward with
+// Travel()/Sleep() while frozen, and advances at the configured factor
+// while scaled, instead of always tracking the real wall clock. It reads
+// Now() minus a fixed epoch, so it only moves forward while Now() does:
+// Unfreeze() after a Travel(), or a TravelTo() into the past, moves it back.
+// Use it in place of raw monotonic timing code (e.g. a runtime-clock-based
+// counter) that needs to keep working under a fake clock.
+func (c *FakeClock) Monotonic() uint64 {
+	c.ensureMonoEpoch(time.Now())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(c.virtualNowLocked().Sub(c.monoEpoch))
+}
+
+// ensureMonoEpoch anchors monoEpoch, the zero point Monotonic() measures
+// from, the first time it is called, and is a no-op on every call after
+// that. It must be called from Freeze() and SetSpeed() too, not just lazily
+// from Monotonic() itself: if the epoch were only captured on the first
+// Monotonic() call, it could land after a frozenTime or base already
+// captured earlier by Freeze()/SetSpeed(), making virtualNowLocked() read as
+// before the epoch, and the subtraction in Monotonic() go negative and wrap around
+// to near math.MaxUint64 once cast to uint64.
+func (c *FakeClock) ensureMonoEpoch(now time.Time) {
+	c.monoOnce.Do(func() { c.monoEpoch = now })
+}
+
+// Since behaves like the package-level Since(), scoped to this clock.
+func (c *FakeClock) Since(t time.Time) time.Duration { return c.Now().Sub(t) }
+
+// Until behaves like the package-level Until(), scoped to this clock.
+func (c *FakeClock) Until(t time.Time) time.Duration { return t.Sub(c.Now()) }
+
+// Sleep behaves like the package-level Sleep(), scoped to this clock.
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	if c.frozen {
+		c.frozenTime = c.frozenTime.Add(d)
+		now := c.frozenTime
+		c.mu.Unlock()
+		c.fireDue(now)
+		c.record(Event{Kind: "Sleep", At: now, Duration: d})
+		return
+	}
+	factor, scaled := c.factor, c.scaled
+	c.mu.Unlock()
+
+	if scaled {
+		time.Sleep(time.Duration(float64(d) / factor))
+	} else {
+		time.Sleep(d)
+	}
+	c.record(Event{Kind: "Sleep", At: c.Now(), Duration: d})
+}
+
+// Freeze behaves like the package-level FreezeNow(), scoped to this clock.
+//
+// The cached time.Time has its monotonic reading stripped (via t.Round(0)):
+// frozenTime is a virtual wall clock that Travel/Sleep jump around
+// arbitrarily, and a monotonic reading is only meaningful for measuring real
+// elapsed time, not an artificial jump. Keeping it around made Sub() against
+// a real time.Time captured elsewhere silently prefer the stale monotonic
+// delta over the (correct) wall-clock one.
+func (c *FakeClock) Freeze() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.ensureMonoEpoch(now)
+	c.frozen = true
+	c.frozenTime = now.Round(0)
+	return c.frozenTime
+}
+
+// Unfreeze behaves like the package-level Unfreeze(), scoped to this clock.
+func (c *FakeClock) Unfreeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frozen = false
+}
+
+// IsFrozen behaves like the package-level IsFrozen(), scoped to this clock.
+func (c *FakeClock) IsFrozen() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.frozen
+}
+
+// Travel behaves like the package-level Travel(), scoped to this clock.
+func (c *FakeClock) Travel(d time.Duration) time.Time {
+	c.mu.Lock()
+	switch {
+	case c.frozen:
+		c.frozenTime = c.frozenTime.Add(d)
+		now := c.frozenTime
+		c.mu.Unlock()
+		c.fireDue(now)
+		c.record(Event{Kind: "Travel", At: now, Duration: d})
+		return now
+	case c.scaled:
+		c.base = c.base.Add(d)
+		now := c.virtualNowLocked()
+		c.mu.Unlock()
+		c.fireDue(now)
+		c.record(Event{Kind: "Travel", At: now, Duration: d})
+		return now
+	default:
+		c.mu.Unlock()
+		panic("You can only time travel after calling Freeze() or SetSpeed()")
+	}
+}
+
+// TravelTo behaves like Travel, except it advances the clock to an absolute
+// instant instead of by a relative Duration. It fires any Timer, Ticker,
+// AfterFunc or Schedule()d callback due by target, in scheduled order.
+func (c *FakeClock) TravelTo(target time.Time) time.Time {
+	c.mu.Lock()
+	switch {
+	case c.frozen:
+		c.frozenTime = target.Round(0)
+		now := c.frozenTime
+		c.mu.Unlock()
+		c.fireDue(now)
+		c.record(Event{Kind: "TravelTo", At: now})
+		return now
+	case c.scaled:
+		c.base = target
+		c.anchor = time.Now()
+		now := target
+		c.mu.Unlock()
+		c.fireDue(now)
+		c.record(Event{Kind: "TravelTo", At: now})
+		return now
+	default:
+		c.mu.Unlock()
+		panic("You can only time travel after calling Freeze() or SetSpeed()")
+	}
+}
+
+// SetSpeed behaves like the package-level SetSpeed(), scoped to this clock.
+func (c *FakeClock) SetSpeed(factor float64) (unscale func()) {
+	if !(factor > 0) {
+		panic("timemachine: SetSpeed requires a positive factor; use Freeze to pause time entirely")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prevScaled, prevAnchor, prevBase, prevFactor := c.scaled, c.anchor, c.base, c.factor
+	c.ensureMonoEpoch(time.Now())
+	now := c.virtualNowLocked()
+
+	c.scaled = true
+	c.anchor = time.Now()
+	c.base = now
+	c.factor = factor
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.scaled = prevScaled
+		c.anchor = prevAnchor
+		c.base = prevBase
+		c.factor = prevFactor
+	}
+}
+
+type clockCtxKey struct{}
+
+// WithClock returns a copy of ctx carrying c, retrievable with FromContext.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockCtxKey{}, c)
+}
+
+// FromContext returns the Clock previously attached with WithClock, or a
+// RealClock if ctx carries none. Library code should prefer this over the
+// package-level globals so callers can inject a FakeClock in tests.
+func FromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockCtxKey{}).(Clock); ok {
+		return c
+	}
+	return RealClock{}
+}
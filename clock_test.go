@@ -0,0 +1,57 @@
+package timemachine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClockIsIsolatedFromGlobal(t *testing.T) {
+	fatalIf(IsFrozen(), t, "package-level clock should not be frozen")
+
+	fc := NewFake(t)
+	fatalIf(!fc.IsFrozen(), t, "NewFake(t) should return a frozen clock")
+	fatalIf(IsFrozen(), t, "freezing a FakeClock should not affect the package-level global")
+
+	n := fc.Now()
+	fc.Travel(time.Hour)
+	fatalIf(fc.Since(n) != time.Hour, t, "FakeClock.Travel should only advance its own instance")
+}
+
+func TestFakeClockCleanupUnfreezes(t *testing.T) {
+	var fc *FakeClock
+	t.Run("child", func(t *testing.T) {
+		fc = NewFake(t)
+	})
+	fatalIf(fc.IsFrozen(), t, "t.Cleanup registered by NewFake should have unfrozen the clock")
+}
+
+func TestWithClockAndFromContext(t *testing.T) {
+	fc := NewFake(t)
+	fc.Travel(24 * time.Hour)
+
+	ctx := WithClock(context.Background(), fc)
+	got := FromContext(ctx)
+	fatalIf(got.Now() != fc.Now(), t, "FromContext should return the Clock installed via WithClock")
+
+	_, ok := FromContext(context.Background()).(RealClock)
+	fatalIf(!ok, t, "FromContext should fall back to RealClock when none was installed")
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	fc := NewFake(t)
+
+	timer := fc.NewTimer(time.Minute)
+	select {
+	case <-timer.C:
+		t.Fatal("Timer should not fire before its deadline")
+	default:
+	}
+
+	fc.Travel(time.Minute)
+	select {
+	case <-timer.C:
+	default:
+		t.Fatal("Timer should fire once Travel() crosses its deadline")
+	}
+}
@@ -0,0 +1,85 @@
+package timemachine
+
+import "time"
+
+// Event is a single recorded action against a FakeClock's virtual timeline,
+// captured while that clock is recording (see StartRecording).
+type Event struct {
+	Kind     string        // "Now", "Sleep", "Travel", "TravelTo" or "Schedule"
+	At       time.Time     // the virtual time the event was observed or fired at
+	Duration time.Duration // the argument to Sleep/Travel, zero for the others
+}
+
+// record appends e to c.events if this clock is currently recording; it is
+// a cheap no-op otherwise.
+func (c *FakeClock) record(e Event) {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	if !c.recording {
+		return
+	}
+	c.events = append(c.events, e)
+}
+
+// StartRecording enables event recording on this clock: every Now, Sleep,
+// Travel/TravelTo call and every callback fired via Schedule/ScheduleIn is
+// appended to Events() along with its virtual timestamp. This gives tests a
+// way to assert exactly when retry/cron-like logic fired against virtual
+// time (e.g. "the retry fired at T+30s, T+90s, T+270s") without wiring up
+// their own event plumbing.
+//
+// Calling StartRecording again clears whatever was previously recorded, so a
+// clock can be reused across more than one recording session without old
+// events leaking into the new one.
+func (c *FakeClock) StartRecording() {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	c.recording = true
+	c.events = nil
+}
+
+// StopRecording disables event recording on this clock and discards
+// everything recorded so far. Use it to end a recording session, or just
+// call StartRecording again to begin a fresh one.
+func (c *FakeClock) StopRecording() {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	c.recording = false
+	c.events = nil
+}
+
+// Events returns every event recorded since StartRecording() was called, in
+// the order they occurred.
+func (c *FakeClock) Events() []Event {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	out := make([]Event, len(c.events))
+	copy(out, c.events)
+	return out
+}
+
+// TravelTo behaves like the package-level Travel(), except it advances the
+// frozen clock to an absolute instant instead of by a relative Duration.
+// See (*FakeClock).TravelTo for details.
+func TravelTo(target time.Time) time.Time {
+	return global.TravelTo(target)
+}
+
+// StartRecording enables event recording on the package-level global clock.
+// See (*FakeClock).StartRecording for details.
+func StartRecording() {
+	global.StartRecording()
+}
+
+// StopRecording disables event recording on the package-level global clock
+// and discards everything recorded so far. See (*FakeClock).StopRecording
+// for details.
+func StopRecording() {
+	global.StopRecording()
+}
+
+// Events returns every event recorded on the package-level global clock
+// since StartRecording() was called.
+func Events() []Event {
+	return global.Events()
+}
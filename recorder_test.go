@@ -0,0 +1,76 @@
+package timemachine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTravelToAbsoluteInstant(t *testing.T) {
+	fc := NewFake(t)
+
+	target := fc.Now().Add(48 * time.Hour)
+	got := fc.TravelTo(target)
+	fatalIf(got != target.Round(0), t, "TravelTo should land exactly on the target instant")
+}
+
+func TestScheduleFiresAtAbsoluteInstant(t *testing.T) {
+	fc := NewFake(t)
+
+	at := fc.Now().Add(30 * time.Second)
+	fired := false
+	fc.Schedule(at, func() { fired = true })
+
+	fc.Travel(29 * time.Second)
+	fatalIf(fired, t, "Schedule()d callback should not fire before its instant")
+
+	fc.Travel(time.Second)
+	fatalIf(!fired, t, "Schedule()d callback should fire once Travel() reaches its instant")
+}
+
+func TestRecorderCapturesScheduleFireTimes(t *testing.T) {
+	fc := NewFake(t)
+	fc.StartRecording()
+	defer fc.StopRecording()
+
+	start := fc.Now()
+	fc.ScheduleIn(30*time.Second, func() {})
+	fc.ScheduleIn(90*time.Second, func() {})
+	fc.ScheduleIn(270*time.Second, func() {})
+
+	fc.Travel(5 * time.Minute)
+
+	var fired []time.Duration
+	for _, e := range fc.Events() {
+		if e.Kind == "Schedule" {
+			fired = append(fired, e.At.Sub(start))
+		}
+	}
+
+	want := []time.Duration{30 * time.Second, 90 * time.Second, 270 * time.Second}
+	fatalIf(len(fired) != len(want), t, "expected %d Schedule events, got %d: %v", len(want), len(fired), fired)
+	for i, d := range want {
+		fatalIf(fired[i] != d, t, "Schedule event %d fired at %v, want %v", i, fired[i], d)
+	}
+}
+
+func TestStartRecordingClearsPriorEvents(t *testing.T) {
+	fc := NewFake(t)
+	fc.StartRecording()
+	fc.Travel(time.Second)
+	fatalIf(len(fc.Events()) == 0, t, "expected at least one event recorded before restarting")
+
+	fc.StartRecording()
+	fatalIf(len(fc.Events()) != 0, t, "StartRecording should clear events left over from a previous session")
+}
+
+func TestStopRecordingDiscardsEvents(t *testing.T) {
+	fc := NewFake(t)
+	fc.StartRecording()
+	fc.Travel(time.Second)
+	fc.StopRecording()
+
+	fatalIf(len(fc.Events()) != 0, t, "StopRecording should discard previously recorded events")
+
+	fc.Travel(time.Second)
+	fatalIf(len(fc.Events()) != 0, t, "no events should be recorded once StopRecording has been called")
+}
@@ -20,6 +20,19 @@
 //   }
 // }
 //
+// timemachine.NewTimer, NewTicker, After, AfterFunc and Tick mirror their
+// time package counterparts, but fire against the frozen clock: a Travel()
+// or Sleep() that crosses a pending deadline delivers it immediately, in
+// scheduled order. BlockUntil(n) lets a test wait until n such timers and
+// callbacks are pending before calling Travel(), instead of a flaky
+// sleep-and-retry loop.
+//
+// All of the above are thin wrappers around a single package-level
+// *FakeClock (see clock.go). Library code that wants to run fully parallel
+// tests (t.Parallel) without trampling that shared clock should use
+// NewFake(t) to get its own instance instead, or accept a Clock parameter
+// and pull one out of a context with WithClock/FromContext.
+//
 // Inspired by HTTP mocking library Gock:
 //     https://github.com/h2non/gock
 //
@@ -31,52 +44,52 @@
 package timemachine
 
 import (
-	"sync"
 	"time"
 )
 
-var state struct {
-	sync.Mutex
-	frozen     bool
-	frozenTime time.Time
-}
+// global is the *FakeClock backing every package-level function below, kept
+// around for backward compatibility with code written before the Clock
+// interface existed. New code, especially anything that needs t.Parallel(),
+// should prefer NewFake(t) or threading a Clock explicitly instead.
+var global = &FakeClock{}
 
 //////////////////////////////////////////////////////////////////////////
 // Swap-ins for time.* functions
 
-// Now behaves like time.Now() unless FreezeNow() has been called. In which
-// case, it returns a cached time.Time object which only changes through
-// Sleep() and Travel() functions
+// Now behaves like time.Now() unless FreezeNow() or SetSpeed() is active. If
+// frozen, it returns a cached time.Time object which only changes through
+// Sleep() and Travel() functions. If scaled (and not frozen), it returns
+// base advanced by time.Since(anchor) scaled by the configured speed factor.
 func Now() time.Time {
-	if state.frozen {
-		//fmt.Println("Using frozen time: ", frozenTime)
-		return state.frozenTime
-	} else {
-		return time.Now()
-	}
+	return global.Now()
 }
 
-// Sleep behaves just like time.Sleep() unless FreezeNow has been called.
-// In which case, it does not actually sleep it just moves the cached time forward.
+// Sleep behaves just like time.Sleep() unless FreezeNow() or SetSpeed() is
+// active. If frozen, it does not actually sleep, it just moves the cached
+// time forward, firing any due Timer, Ticker or AfterFunc along the way. If
+// scaled, it sleeps for d/factor of real time, which Now()'s formula reports
+// back as d of virtual elapsed time.
 func Sleep(d time.Duration) {
-	if state.frozen {
-		//fmt.Printf("Artificially moving time forward by %v\n", d)
-		state.frozenTime = state.frozenTime.Add(d)
-	} else {
-		time.Sleep(d)
-	}
+	global.Sleep(d)
 }
 
 // Since should be used instead of time.Since() if you are using this library, as it
 // depends on Now() and FreezeNow() functions
 func Since(t time.Time) time.Duration {
-	return Now().Sub(t)
+	return global.Since(t)
 }
 
 // Until should be used instead of time.Until() if you are using this library, as it
 // depends on Now() and FreezeNow() functions
 func Until(t time.Time) time.Duration {
-	return t.Sub(Now())
+	return global.Until(t)
+}
+
+// Monotonic returns a nanosecond counter that honors
+// FreezeNow()/Travel()/SetSpeed() the same way Now() does. See
+// (*FakeClock).Monotonic for details.
+func Monotonic() uint64 {
+	return global.Monotonic()
 }
 
 //////////////////////////////////////////////////////////////////////////
@@ -85,36 +98,48 @@ func Until(t time.Time) time.Duration {
 // FreezeNow should be used in tests to trigger this library's core behaviour,
 // caching time.Now(). You should ONLY use this in test code.
 func FreezeNow() time.Time {
-	state.Lock()
-	defer state.Unlock()
-	state.frozen = true
-	state.frozenTime = time.Now()
-	return state.frozenTime
+	return global.Freeze()
 }
 
 // Unfreeze cleans things up, reverting to production mode. Use the FreezeNow(), defer Unfreeze()
 // idiom.
 func Unfreeze() {
-	state.Lock()
-	defer state.Unlock()
-	state.frozen = false
+	global.Unfreeze()
 }
 
 // IsFrozen tells you if FreezeNow() has been called without Unfreeze()
 func IsFrozen() bool {
-	return state.frozen
+	return global.IsFrozen()
 }
 
 // Travel allows you to increment cached time by time.Duration. Only intended for test mode, not
-// production mode. Panic's if called outside FreezeNow() and Unfreeze() block.
+// production mode. Panic's if called outside FreezeNow() and Unfreeze() block, unless SetSpeed()
+// is active, in which case it shifts the scaled clock's base forward by d instead.
 // You more explicitly communicate your intent using Travel() than Sleep().
+//
+// Travel fires any Timer, Ticker or AfterFunc scheduled via this package
+// whose deadline falls within the traveled interval, in scheduled order.
 func Travel(d time.Duration) time.Time {
-	if !state.frozen {
-		panic("You can only time travel after calling FreezeNow()")
-	} else {
-		state.Lock()
-		defer state.Unlock()
-		state.frozenTime = state.frozenTime.Add(d)
-		return state.frozenTime
-	}
+	return global.Travel(d)
+}
+
+// SetSpeed makes Now() advance at factor times the real wall clock instead
+// of being fully frozen: a factor of 2 runs virtual time twice as fast as
+// real time, 0.5 runs it at half speed. Sleep(d) sleeps for d/factor of real
+// time while still reporting d of virtual elapsed time. This lets you
+// simulate e.g. a 24 hour expiry within seconds of wall time without
+// forcing a full freeze.
+//
+// FreezeNow always wins: if time is frozen, Now() returns the frozen time
+// regardless of any SetSpeed() in effect.
+//
+// SetSpeed panics if factor is not strictly positive; use FreezeNow to pause
+// time entirely instead of scaling it to zero.
+//
+// It returns an unscale token that restores whatever speed (or lack of one)
+// was previously in effect, so SetSpeed calls can be nested:
+//
+//   defer timemachine.SetSpeed(60)()
+func SetSpeed(factor float64) (unscale func()) {
+	return global.SetSpeed(factor)
 }
@@ -89,6 +89,91 @@ func TestTimeTravel(t *testing.T) {
 
 }
 
+func TestSetSpeed(t *testing.T) {
+	fatalIf(IsFrozen(), t, "Time should not be frozen")
+
+	n := Now()
+	unscale := SetSpeed(100)
+	time.Sleep(SLEEP)
+	diff := Since(n)
+	unscale()
+
+	fatalIf(diff < 100*SLEEP, t, "SetSpeed(100) should advance Now() ~100x faster than real time")
+}
+
+func TestSetSpeedSleepReportsVirtualDuration(t *testing.T) {
+	unscale := SetSpeed(1000)
+	defer unscale()
+
+	n := Now()
+	Sleep(time.Second)
+	diff := Since(n)
+	errorIf(diff < time.Second, t, "Sleep(d) under SetSpeed should report at least d of virtual elapsed time")
+}
+
+func TestSetSpeedNestingRestoresPreviousSpeed(t *testing.T) {
+	fatalIf(IsFrozen(), t, "Time should not be frozen")
+
+	outer := SetSpeed(10)
+	func() {
+		inner := SetSpeed(1000)
+		defer inner()
+	}()
+	unscale := outer
+	unscale()
+
+	fatalIf(global.scaled, t, "Unscale tokens should restore back to unscaled real time")
+}
+
+func TestSetSpeedRejectsNonPositiveFactor(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("SetSpeed(0) should panic; use FreezeNow to pause time entirely")
+		}
+	}()
+	SetSpeed(0)
+}
+
+func TestSinceAcrossFreezeBoundaryIsNotStale(t *testing.T) {
+	before := time.Now()
+
+	_ = FreezeNow()
+	defer Unfreeze()
+	Travel(24 * time.Hour)
+
+	diff := Since(before)
+	fatalIf(diff < 24*time.Hour, t, "Since() should reflect the full Travel() jump, not a stale monotonic delta")
+}
+
+func TestMonotonicHonorsFrozenClock(t *testing.T) {
+	_ = FreezeNow()
+	defer Unfreeze()
+
+	m0 := Monotonic()
+	Travel(time.Hour)
+	m1 := Monotonic()
+
+	fatalIf(m1-m0 < uint64(time.Hour), t, "Monotonic() should jump forward with Travel() while frozen")
+}
+
+func TestMonotonicFirstCallIsSane(t *testing.T) {
+	fc := NewFake(t)
+
+	m0 := fc.Monotonic()
+	fatalIf(m0 > uint64(time.Second), t,
+		"Monotonic()'s first call should read close to zero, got %d (an epoch anchored after frozenTime wraps around to a huge value instead)", m0)
+}
+
+func TestFreezeWinsOverSetSpeed(t *testing.T) {
+	unscale := SetSpeed(1000)
+	defer unscale()
+
+	n := FreezeNow()
+	defer Unfreeze()
+	time.Sleep(SLEEP)
+	fatalIf(Now() != n, t, "FreezeNow() should win over an active SetSpeed()")
+}
+
 //////////////////////////////////////////////////////////////
 // helper functions
 
@@ -0,0 +1,367 @@
+package timemachine
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Timer mirrors time.Timer. When its owning clock is not frozen it simply
+// wraps a real *time.Timer; when frozen, it only fires once Travel() or
+// Sleep() advances the frozen clock past its deadline.
+type Timer struct {
+	C <-chan time.Time
+
+	real  *time.Timer
+	w     *waiter
+	owner *FakeClock
+}
+
+// Stop behaves like (*time.Timer).Stop: it prevents the Timer from firing.
+// It returns true if the call stops the timer, false if the timer has
+// already expired or been stopped.
+func (t *Timer) Stop() bool {
+	if t.real != nil {
+		return t.real.Stop()
+	}
+	return t.owner.removeWaiter(t.w)
+}
+
+// Reset behaves like (*time.Timer).Reset, changing the timer to expire
+// after duration d.
+func (t *Timer) Reset(d time.Duration) bool {
+	if t.real != nil {
+		return t.real.Reset(d)
+	}
+	active := t.owner.removeWaiter(t.w)
+	t.w.deadline = t.owner.Now().Add(d)
+	t.owner.addWaiter(t.w)
+	return active
+}
+
+// Ticker mirrors time.Ticker, honoring the frozen/traveling clock the same
+// way Timer does.
+type Ticker struct {
+	C <-chan time.Time
+
+	real  *time.Ticker
+	w     *waiter
+	owner *FakeClock
+}
+
+// Stop turns off the ticker. No more ticks will be sent.
+func (t *Ticker) Stop() {
+	if t.real != nil {
+		t.real.Stop()
+		return
+	}
+	t.owner.removeWaiter(t.w)
+}
+
+// Reset stops the ticker and resets its period to the specified duration.
+func (t *Ticker) Reset(d time.Duration) {
+	if t.real != nil {
+		t.real.Reset(d)
+		return
+	}
+	t.owner.removeWaiter(t.w)
+	t.w.period = d
+	t.w.deadline = t.owner.Now().Add(d)
+	t.owner.addWaiter(t.w)
+}
+
+// waiter is a single pending timer/ticker/AfterFunc callback, scheduled
+// against a FakeClock instead of the real clock.
+type waiter struct {
+	deadline  time.Time
+	period    time.Duration // zero for a one-shot Timer
+	c         chan time.Time
+	fn        func(time.Time)
+	inline    bool // run fn synchronously on the advancing goroutine
+	scheduled bool // created via Schedule/ScheduleIn; fireDue logs an Event when it fires
+	index     int
+}
+
+// waiterHeap is a container/heap of waiters ordered by deadline, so Travel
+// and Sleep can pop and fire everything due in scheduled order.
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int           { return len(h) }
+func (h waiterHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
+
+func (c *FakeClock) addWaiter(w *waiter) {
+	c.initSched()
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	heap.Push(&c.pending, w)
+	c.cond.Broadcast()
+}
+
+func (c *FakeClock) removeWaiter(w *waiter) bool {
+	c.initSched()
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	if w.index < 0 || w.index >= len(c.pending) || c.pending[w.index] != w {
+		return false
+	}
+	heap.Remove(&c.pending, w.index)
+	c.cond.Broadcast()
+	return true
+}
+
+// fireDue pops and delivers every waiter whose deadline has passed, in
+// scheduled order. Tickers are re-enqueued at deadline+period, so a single
+// Travel() that crosses several periods fires each one in turn.
+//
+// Each waiter is popped (and, if periodic, re-enqueued) while c.schedMu is
+// held, but the lock is released before its callback is delivered. An
+// inline callback (Schedule/ScheduleIn/AfterFuncSync) runs synchronously
+// right here, and it's free to call back into Schedule, NewTimer, Travel and
+// so on; those all need to re-lock c.schedMu, which would deadlock forever
+// against a non-reentrant mutex still held around the delivery. Looping
+// instead of collecting every due waiter up front also means a callback
+// that reschedules itself (e.g. a retry loop) keeps getting picked up by
+// this same Travel()/Sleep() call, as long as its new deadline still falls
+// within it. While a waiter is delivered, a frozen clock reads that waiter's
+// deadline, so a ScheduleIn(d) from inside its callback lands at deadline+d;
+// the clock reads now again once every due waiter has been delivered.
+func (c *FakeClock) fireDue(now time.Time) {
+	c.initSched()
+	for {
+		c.schedMu.Lock()
+		if len(c.pending) == 0 || c.pending[0].deadline.After(now) {
+			c.cond.Broadcast()
+			c.schedMu.Unlock()
+			c.mu.Lock()
+			if c.frozen && c.frozenTime.Before(now) {
+				c.frozenTime = now
+			}
+			c.mu.Unlock()
+			return
+		}
+		w := heap.Pop(&c.pending).(*waiter)
+		firedAt := w.deadline
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+			heap.Push(&c.pending, w)
+		}
+		c.cond.Broadcast()
+		c.schedMu.Unlock()
+
+		c.mu.Lock()
+		if c.frozen {
+			c.frozenTime = firedAt
+		}
+		c.mu.Unlock()
+		deliver(w, firedAt)
+		if w.scheduled {
+			c.record(Event{Kind: "Schedule", At: firedAt})
+		}
+	}
+}
+
+func deliver(w *waiter, t time.Time) {
+	if w.fn != nil {
+		if w.inline {
+			w.fn(t)
+		} else {
+			go w.fn(t)
+		}
+		return
+	}
+	select {
+	case w.c <- t:
+	default:
+	}
+}
+
+// NewTimer behaves like time.NewTimer, except that while this clock is
+// frozen the returned Timer only fires once Travel() or Sleep() advances the
+// frozen clock past its deadline.
+func (c *FakeClock) NewTimer(d time.Duration) *Timer {
+	if !c.IsFrozen() {
+		rt := time.NewTimer(d)
+		return &Timer{C: rt.C, real: rt}
+	}
+	w := &waiter{deadline: c.Now().Add(d), c: make(chan time.Time, 1)}
+	c.addWaiter(w)
+	return &Timer{C: w.c, w: w, owner: c}
+}
+
+// tickerBufferSize is the channel capacity given to a frozen Ticker's C.
+// fireDue can pop and re-enqueue a periodic waiter several times in a single
+// Travel()/Sleep() call (one per period crossed), all before the caller
+// gets a chance to drain C, so a capacity of 1 (matching a one-shot Timer)
+// would silently drop every tick past the first. With this buffer, up to
+// tickerBufferSize undrained ticks stay observable afterward; a tick fired
+// while C already holds that many is dropped.
+const tickerBufferSize = 4096
+
+// NewTicker behaves like time.NewTicker, honoring the frozen clock the same
+// way NewTimer does.
+func (c *FakeClock) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("timemachine: non-positive interval for NewTicker")
+	}
+	if !c.IsFrozen() {
+		rt := time.NewTicker(d)
+		return &Ticker{C: rt.C, real: rt}
+	}
+	w := &waiter{deadline: c.Now().Add(d), period: d, c: make(chan time.Time, tickerBufferSize)}
+	c.addWaiter(w)
+	return &Ticker{C: w.c, w: w, owner: c}
+}
+
+// After behaves like time.After, honoring the frozen clock.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+// Tick behaves like time.Tick, honoring the frozen clock. As with time.Tick,
+// the returned Ticker can never be stopped; callers that need to Stop()
+// should use NewTicker instead.
+func (c *FakeClock) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return c.NewTicker(d).C
+}
+
+// AfterFunc behaves like time.AfterFunc: it waits for duration d to elapse
+// and then calls f in its own goroutine. While frozen, elapsing means
+// Travel() or Sleep() crossing the deadline.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) *Timer {
+	return c.afterFunc(d, f, false)
+}
+
+// AfterFuncSync behaves like AfterFunc, except f is invoked synchronously,
+// inline on the goroutine calling Travel() or Sleep(), instead of in its own
+// goroutine. This lets tests assert a callback has not fired without
+// resorting to a real time.Sleep() to win a race against a background
+// goroutine. It has no real-clock equivalent, so outside of a frozen clock
+// it behaves exactly like AfterFunc.
+func (c *FakeClock) AfterFuncSync(d time.Duration, f func()) *Timer {
+	return c.afterFunc(d, f, true)
+}
+
+func (c *FakeClock) afterFunc(d time.Duration, f func(), inline bool) *Timer {
+	if !c.IsFrozen() {
+		rt := time.AfterFunc(d, f)
+		return &Timer{real: rt}
+	}
+	w := &waiter{deadline: c.Now().Add(d), fn: func(time.Time) { f() }, inline: inline}
+	c.addWaiter(w)
+	return &Timer{w: w, owner: c}
+}
+
+// Schedule enqueues fn to run once this clock's virtual time reaches the
+// absolute instant at. fn runs synchronously, inline on the goroutine
+// calling Travel()/TravelTo()/Sleep() (the same way AfterFuncSync does),
+// so callbacks scheduled in order are guaranteed to have already run, in
+// that order, by the time the call that crossed their deadline returns.
+// Unlike AfterFunc it takes an absolute time.Time rather than a relative
+// Duration, pairing naturally with TravelTo. If this clock is recording
+// (see StartRecording), the moment it fires is appended to Events().
+func (c *FakeClock) Schedule(at time.Time, fn func()) {
+	w := &waiter{deadline: at, fn: func(time.Time) { fn() }, inline: true, scheduled: true}
+	c.addWaiter(w)
+}
+
+// ScheduleIn is Schedule relative to this clock's current time, i.e.
+// c.Schedule(c.Now().Add(d), fn).
+func (c *FakeClock) ScheduleIn(d time.Duration, fn func()) {
+	c.Schedule(c.Now().Add(d), fn)
+}
+
+// BlockUntil blocks until exactly n waiters are pending in this clock's
+// scheduler: Timers and Tickers created while it is frozen (including those
+// behind After() and Tick()), AfterFunc/AfterFuncSync callbacks and
+// Schedule()d callbacks that have not yet fired or been stopped (a Ticker
+// stays pending until stopped). A waiter counts from the moment it is
+// created, whether or not a goroutine is blocked receiving from its channel;
+// Sleep() adds no waiter. Use it in frozen-clock tests to know that a
+// goroutine has created its timer before calling Travel(), instead of
+// relying on a real time.Sleep()-and-retry loop.
+func (c *FakeClock) BlockUntil(n int) {
+	c.initSched()
+	c.schedMu.Lock()
+	defer c.schedMu.Unlock()
+	for len(c.pending) != n {
+		c.cond.Wait()
+	}
+}
+
+// NewTimer behaves like time.NewTimer, except that while FreezeNow() is
+// active the returned Timer only fires once Travel() or Sleep() advances the
+// frozen clock past its deadline.
+func NewTimer(d time.Duration) *Timer {
+	return global.NewTimer(d)
+}
+
+// NewTicker behaves like time.NewTicker, honoring the frozen clock the same
+// way NewTimer does.
+func NewTicker(d time.Duration) *Ticker {
+	return global.NewTicker(d)
+}
+
+// After behaves like time.After, honoring the frozen clock.
+func After(d time.Duration) <-chan time.Time {
+	return global.After(d)
+}
+
+// Tick behaves like time.Tick, honoring the frozen clock. As with time.Tick,
+// the returned Ticker can never be stopped; callers that need to Stop()
+// should use NewTicker instead.
+func Tick(d time.Duration) <-chan time.Time {
+	return global.Tick(d)
+}
+
+// AfterFunc behaves like time.AfterFunc: it waits for duration d to elapse
+// and then calls f in its own goroutine. While frozen, elapsing means
+// Travel() or Sleep() crossing the deadline.
+func AfterFunc(d time.Duration, f func()) *Timer {
+	return global.AfterFunc(d, f)
+}
+
+// AfterFuncSync behaves like AfterFunc, except f is invoked synchronously,
+// inline on the goroutine calling Travel() or Sleep(), instead of in its own
+// goroutine. See (*FakeClock).AfterFuncSync for details.
+func AfterFuncSync(d time.Duration, f func()) *Timer {
+	return global.AfterFuncSync(d, f)
+}
+
+// BlockUntil blocks until exactly n waiters (Timers, Tickers, After()
+// channels and scheduled callbacks) are pending on the package-level clock.
+// See (*FakeClock).BlockUntil for details.
+func BlockUntil(n int) {
+	global.BlockUntil(n)
+}
+
+// Schedule enqueues fn to run once the frozen clock's virtual time reaches
+// the absolute instant at. See (*FakeClock).Schedule for details.
+func Schedule(at time.Time, fn func()) {
+	global.Schedule(at, fn)
+}
+
+// ScheduleIn enqueues fn to run once the frozen clock's virtual time
+// advances by d. See (*FakeClock).ScheduleIn for details.
+func ScheduleIn(d time.Duration, fn func()) {
+	global.ScheduleIn(d, fn)
+}
@@ -0,0 +1,95 @@
+package timemachine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerFiresOnTravel(t *testing.T) {
+	fc := NewFake(t)
+
+	timer := fc.NewTimer(time.Hour)
+	select {
+	case <-timer.C:
+		t.Fatal("Timer should not fire before its deadline")
+	default:
+	}
+
+	fc.Travel(time.Hour)
+	select {
+	case <-timer.C:
+	default:
+		t.Fatal("Timer should fire once Travel() crosses its deadline")
+	}
+}
+
+func TestTickerFiresRepeatedlyInOrder(t *testing.T) {
+	fc := NewFake(t)
+
+	start := fc.Now()
+	ticker := fc.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	fc.Travel(3*time.Minute + time.Second)
+	for i := 0; i < 3; i++ {
+		select {
+		case tick := <-ticker.C:
+			want := start.Add(time.Duration(i+1) * time.Minute)
+			fatalIf(!tick.Equal(want), t, "tick %d fired at %v, want %v", i, tick, want)
+		default:
+			t.Fatalf("Ticker should have fired %d times, only saw %d", 3, i)
+		}
+	}
+}
+
+func TestAfterFuncSyncRunsInline(t *testing.T) {
+	fc := NewFake(t)
+
+	fired := false
+	fc.AfterFuncSync(time.Minute, func() { fired = true })
+
+	fatalIf(fired, t, "AfterFuncSync callback should not fire before its deadline")
+	fc.Travel(time.Minute)
+	fatalIf(!fired, t, "AfterFuncSync callback should have fired synchronously during Travel()")
+}
+
+func TestBlockUntil(t *testing.T) {
+	fc := NewFake(t)
+
+	done := make(chan struct{})
+	go func() {
+		<-fc.After(time.Hour)
+		close(done)
+	}()
+
+	fc.BlockUntil(1)
+	fc.Travel(time.Hour)
+	<-done
+}
+
+func TestScheduleCanRescheduleItselfWithinOneTravel(t *testing.T) {
+	fc := NewFake(t)
+	fc.StartRecording()
+	defer fc.StopRecording()
+
+	start := fc.Now()
+	var retry func()
+	retry = func() {
+		fc.ScheduleIn(time.Minute, retry)
+	}
+	retry()
+
+	fc.Travel(3 * time.Minute)
+
+	var fired []time.Duration
+	for _, e := range fc.Events() {
+		if e.Kind == "Schedule" {
+			fired = append(fired, e.At.Sub(start))
+		}
+	}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}
+	fatalIf(len(fired) != len(want), t, "expected %d self-rescheduled fires, got %d: %v", len(want), len(fired), fired)
+	for i, d := range want {
+		fatalIf(fired[i] != d, t, "reschedule %d fired at %v, want %v", i, fired[i], d)
+	}
+}